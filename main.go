@@ -1,11 +1,22 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/ascii85"
+	"encoding/asn1"
 	"encoding/base32"
 	"encoding/base64"
+	"encoding/binary"
+	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"html"
@@ -19,14 +30,46 @@ import (
 	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/blake2b"
 	"golang.org/x/text/encoding/ianaindex"
+	"golang.org/x/text/transform"
 	"golang.org/x/text/unicode/runenames"
 
 	"github.com/x448/float16"
 )
 
-type modeFunc = func([]byte) ([]byte, error)
+// modeFunc reads the full input from r, transforms it, and writes the
+// result to w. Implementations that can operate incrementally (base32,
+// base64, hex, qp, and IANA encodings) stream directly between r and w;
+// the rest are adapted from the simpler []byte-in/[]byte-out form via
+// buffered.
+type modeFunc = func(w io.Writer, r io.Reader) error
+
+// bytesFunc is the signature used by modes that are easier to express as
+// a single in-memory transform (e.g. text-shaped or structured formats
+// that need to see the whole input before producing output).
+type bytesFunc = func([]byte) ([]byte, error)
+
+// buffered adapts a bytesFunc into a modeFunc by reading r fully into
+// memory before calling f and writing its result to w.
+func buffered(f bytesFunc) modeFunc {
+	return func(w io.Writer, r io.Reader) error {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		out, err := f(b)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(out)
+		return err
+	}
+}
 
 var modes = map[string]struct{ decoder, encoder modeFunc }{
 	"base32":           {base32Dec, base32Enc},
@@ -34,20 +77,50 @@ var modes = map[string]struct{ decoder, encoder modeFunc }{
 	"base32-hex":       {base32HexDec, base32HexEnc},
 	"base64":           {base64Dec, base64Enc},
 	"base64-url":       {base64URLDec, base64URLEnc},
-	"codepoint":        {nil, codepointEnc},
-	"go":               {goDec, goEnc},
+	"ascii85":          {buffered(ascii85Dec), buffered(ascii85Enc)},
+	"z85":              {buffered(z85Dec), buffered(z85Enc)},
+	"der":              {buffered(derDec), buffered(derEnc)},
+	"varint":           {buffered(varintDec), buffered(varintEnc)},
+	"zigzag":           {buffered(zigzagDec), buffered(zigzagEnc)},
+	"codepoint":        {nil, buffered(codepointEnc)},
+	"go":               {buffered(goDec), buffered(goEnc)},
 	"hex":              {hexDec, hexEnc},
-	"hex-extended":     {nil, hexExtEnc},
-	"html":             {htmlDec, htmlEnc},
-	"json":             {jsonDec, jsonEnc},
+	"hex-extended":     {nil, buffered(hexExtEnc)},
+	"html":             {buffered(htmlDec), buffered(htmlEnc)},
+	"json":             {buffered(jsonDec), buffered(jsonEnc)},
 	"qp":               {quotedPrintableDec, quotedPrintableEnc},
-	"rot13":            {rot13, rot13},
-	"url-path":         {urlPathDec, urlPathEnc},
-	"url-query":        {urlQueryDec, urlQueryEnc},
-	"float32-hex":      {float32hexDec, float32hexEnc},
-	"float16-hex":      {float16hexDec, float16hexEnc},
+	"rot13":            {buffered(rot13), buffered(rot13)},
+	"url-path":         {buffered(urlPathDec), buffered(urlPathEnc)},
+	"url-query":        {buffered(urlQueryDec), buffered(urlQueryEnc)},
+	"float32-hex":      {buffered(float32hexDec), buffered(float32hexEnc)},
+	"float16-hex":      {buffered(float16hexDec), buffered(float16hexEnc)},
+	"csv":              {csvDec, csvEnc},
+	"tsv":              {tsvDec, tsvEnc},
+	"sha256":           {nil, sha256Enc},
+	"sha512":           {nil, sha512Enc},
+	"md5":              {nil, md5Enc},
+	"blake2b":          {nil, blake2bEnc},
+	"bcrypt":           {bcryptVerify, bcryptEnc},
+	"argon2id":         {argon2idVerify, argon2idEnc},
 }
 
+// csvDelim, csvHeader, and hashCost are declared at package scope, rather
+// than as locals in main like -strip/-emit, because the mode entries in
+// the modes map above are built at package init time and need a
+// flag.Value to read from once main calls flag.Parse.
+var (
+	csvDelim  = flag.String("delim", "", "field delimiter override for csv/tsv modes (default ',' for csv, tab for tsv)")
+	csvQuote  = flag.String("quote", `"`, `quote character for csv/tsv modes; encoding/csv only supports '"', so any other value is rejected`)
+	csvHeader = flag.Bool("header", false, "treat the first csv/tsv row as a header: decode emits JSON objects, encode expects JSON objects")
+	hashCost  = flag.Int("cost", bcrypt.DefaultCost, "cost parameter for bcrypt (4-31) or iteration count for argon2id")
+)
+
+// errHashMismatch is returned by the bcrypt/argon2id decode (verify)
+// direction when the supplied password doesn't match the hash; exec
+// returns it and main reports it on stderr and exits 1, matching a
+// successful match's exit 0.
+var errHashMismatch = errors.New("password does not match hash")
+
 func main() {
 	encode := os.Args[0] == "encoder-ring"
 	flag.BoolVar(&encode, "encode", encode, "encode rather than decode")
@@ -56,13 +129,19 @@ func main() {
 	flag.BoolVar(strip, "s", true, "shortcut for -strip")
 	emit := flag.Bool("emit", true, "emit trailing newline (UTF-8)")
 	flag.BoolVar(emit, "t", true, "shortcut for -emit")
+	detect := flag.Bool("detect", false, "guess the most likely decoding of stdin instead of running a fixed MODE")
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), `Usage of decoder-ring %s:
 
     decoder-ring [-encode] <MODE>
+    decoder-ring -detect
 
 MODE choices are %s, or an IANA encoding name. Modes marked with * are encode only.
 
+-detect inspects stdin, scores every registered decoder by alphabet match,
+round-trip success, printable ratio, and UTF-8 validity, prints the ranked
+candidates to stderr, and writes the top candidate's decoded output to stdout.
+
 As a convenience feature, when this executable is symlinked as 'encoder-ring', -e defaults to true.
 
 `, getVersion(), getModes())
@@ -70,6 +149,11 @@ As a convenience feature, when this executable is symlinked as 'encoder-ring', -
 	}
 	flag.Parse()
 
+	if *detect {
+		runDetect()
+		return
+	}
+
 	modeStr := flag.Arg(0)
 	mode := modes[modeStr].decoder
 	if encode {
@@ -80,9 +164,18 @@ As a convenience feature, when this executable is symlinked as 'encoder-ring', -
 		i, err := ianaindex.IANA.Encoding(modeStr)
 		if err == nil {
 			if encode {
-				mode = i.NewEncoder().Bytes
+				mode = func(w io.Writer, r io.Reader) error {
+					tw := transform.NewWriter(w, i.NewEncoder())
+					if _, err := io.Copy(tw, r); err != nil {
+						return err
+					}
+					return tw.Close()
+				}
 			} else {
-				mode = i.NewDecoder().Bytes
+				mode = func(w io.Writer, r io.Reader) error {
+					_, err := io.Copy(w, i.NewDecoder().Reader(r))
+					return err
+				}
 			}
 		}
 	}
@@ -102,6 +195,141 @@ As a convenience feature, when this executable is symlinked as 'encoder-ring', -
 	}
 }
 
+// detectCandidate is one registered mode's result when run against stdin
+// under -detect, ranked by detectScore.
+type detectCandidate struct {
+	name    string
+	score   float64
+	decoded []byte
+}
+
+func runDetect() {
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	candidates := detectCandidates(b)
+	if len(candidates) == 0 {
+		fmt.Fprintln(os.Stderr, "decoder-ring: no candidate encoding matched")
+		os.Exit(1)
+	}
+
+	for _, c := range candidates {
+		fmt.Fprintf(os.Stderr, "%-18s score=%.2f\n", c.name, c.score)
+	}
+
+	os.Stdout.Write(candidates[0].decoded)
+	if n := len(candidates[0].decoded); n == 0 || candidates[0].decoded[n-1] != '\n' {
+		fmt.Fprintln(os.Stdout)
+	}
+}
+
+func detectCandidates(input []byte) []detectCandidate {
+	names := make([]string, 0, len(modes))
+	for name := range modes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var candidates []detectCandidate
+	for _, name := range names {
+		dec := modes[name].decoder
+		if dec == nil {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := dec(&buf, bytes.NewReader(input)); err != nil {
+			continue
+		}
+		decoded := buf.Bytes()
+		candidates = append(candidates, detectCandidate{
+			name:    name,
+			score:   detectScore(name, input, decoded),
+			decoded: decoded,
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	return candidates
+}
+
+// detectScore combines four independent heuristics, each worth up to
+// 0.25, into a single 0-1 confidence score: does the raw input's
+// alphabet look right for this mode, does re-encoding the decoded
+// output round-trip back to the input, how much of the decoded output
+// is printable text, and is it valid UTF-8.
+func detectScore(name string, input, decoded []byte) float64 {
+	var score float64
+	if detectAlphabetMatches(name, input) {
+		score += 0.25
+	}
+	if enc := modes[name].encoder; enc != nil {
+		var buf bytes.Buffer
+		if err := enc(&buf, bytes.NewReader(decoded)); err == nil {
+			if bytes.Equal(bytes.TrimRight(buf.Bytes(), "\n"), bytes.TrimRight(input, "\n")) {
+				score += 0.25
+			}
+		}
+	}
+	score += 0.25 * printableRatio(decoded)
+	if utf8.Valid(decoded) {
+		score += 0.25
+	}
+	return score
+}
+
+func printableRatio(b []byte) float64 {
+	if len(b) == 0 {
+		return 0
+	}
+	total, printable := 0, 0
+	for _, r := range string(b) {
+		total++
+		if unicode.IsPrint(r) || unicode.IsSpace(r) {
+			printable++
+		}
+	}
+	return float64(printable) / float64(total)
+}
+
+func detectAlphabetMatches(name string, input []byte) bool {
+	trimmed := bytes.TrimSpace(input)
+	if len(trimmed) == 0 {
+		return false
+	}
+	allMatch := func(pred func(byte) bool) bool {
+		for _, b := range trimmed {
+			if !pred(b) {
+				return false
+			}
+		}
+		return true
+	}
+	switch name {
+	case "base64", "base64-url":
+		return allMatch(func(b byte) bool {
+			return b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9' ||
+				b == '+' || b == '/' || b == '-' || b == '_' || b == '='
+		})
+	case "hex":
+		return len(trimmed)%2 == 0 && allMatch(func(b byte) bool {
+			return b >= '0' && b <= '9' || b >= 'a' && b <= 'f' || b >= 'A' && b <= 'F'
+		})
+	case "base32", "base32-hex", "base32-crockford":
+		return allMatch(func(b byte) bool {
+			return b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '=' || b == '-'
+		})
+	case "ascii85":
+		return allMatch(func(b byte) bool { return b >= '!' && b <= 'u' || b == 'z' })
+	case "z85":
+		return allMatch(func(b byte) bool { return strings.IndexByte(z85Alphabet, b) >= 0 })
+	default:
+		return true
+	}
+}
+
 func getVersion() string {
 	if i, ok := debug.ReadBuildInfo(); ok {
 		return i.Main.Version
@@ -122,40 +350,55 @@ func getModes() string {
 }
 
 func exec(f modeFunc, stripNewline, emitNewline bool) error {
-	b, err := io.ReadAll(os.Stdin)
-	if err != nil {
-		return err
-	}
+	var in io.Reader = os.Stdin
 	if stripNewline {
-		if len(b) > 0 && b[len(b)-1] == '\n' {
-			b = b[:len(b)-1]
-		}
+		in = newTrailingNewlineStripper(in)
 	}
-	b, err = f(b)
-	if err != nil {
+	if err := f(os.Stdout, in); err != nil {
 		return err
 	}
-	var trailer string
 	if emitNewline {
-		trailer = "\n"
+		_, err := io.WriteString(os.Stdout, "\n")
+		return err
 	}
-	_, err = io.Copy(os.Stdout, io.MultiReader(
-		bytes.NewReader(b),
-		strings.NewReader(trailer),
-	))
-	return err
+	return nil
 }
 
-func hexEnc(src []byte) (dst []byte, err error) {
-	dst = make([]byte, hex.EncodedLen(len(src)))
-	hex.Encode(dst, src)
-	return
+// trailingNewlineStripper drops a single trailing '\n' from the
+// underlying reader without buffering the rest of the stream, so large
+// inputs don't need to be read into memory just to check their last byte.
+type trailingNewlineStripper struct {
+	br *bufio.Reader
 }
 
-func hexDec(src []byte) ([]byte, error) {
-	dst := make([]byte, hex.DecodedLen(len(src)))
-	n, err := hex.Decode(dst, src)
-	return dst[:n], err
+func newTrailingNewlineStripper(r io.Reader) io.Reader {
+	return &trailingNewlineStripper{br: bufio.NewReader(r)}
+}
+
+func (t *trailingNewlineStripper) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	n, err := t.br.Read(p)
+	if n > 0 && p[n-1] == '\n' {
+		if _, peekErr := t.br.Peek(1); peekErr == io.EOF {
+			n--
+			if n == 0 {
+				return 0, io.EOF
+			}
+		}
+	}
+	return n, err
+}
+
+func hexEnc(w io.Writer, r io.Reader) error {
+	_, err := io.Copy(hex.NewEncoder(w), r)
+	return err
+}
+
+func hexDec(w io.Writer, r io.Reader) error {
+	_, err := io.Copy(w, hex.NewDecoder(r))
+	return err
 }
 
 func float32hexDec(src []byte) ([]byte, error) {
@@ -216,34 +459,156 @@ func float16hexEnc(src []byte) ([]byte, error) {
 	return []byte(dst.String()), nil
 }
 
+func varintDec(src []byte) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimSpace(string(src)))
+	if err != nil {
+		return nil, err
+	}
+	var dst strings.Builder
+	for len(b) > 0 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("varint: invalid varint bytes")
+		}
+		if dst.Len() > 0 {
+			dst.WriteByte(' ')
+		}
+		fmt.Fprintf(&dst, "%d", v)
+		b = b[n:]
+	}
+	return []byte(dst.String()), nil
+}
+
+func varintEnc(src []byte) ([]byte, error) {
+	words := strings.Fields(string(src))
+	buf := make([]byte, binary.MaxVarintLen64)
+	var dst bytes.Buffer
+	for _, word := range words {
+		v, err := strconv.ParseUint(word, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		n := binary.PutUvarint(buf, v)
+		dst.WriteString(hex.EncodeToString(buf[:n]))
+	}
+	return dst.Bytes(), nil
+}
+
+func zigzagDec(src []byte) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimSpace(string(src)))
+	if err != nil {
+		return nil, err
+	}
+	var dst strings.Builder
+	for len(b) > 0 {
+		v, n := binary.Varint(b)
+		if n <= 0 {
+			return nil, fmt.Errorf("zigzag: invalid varint bytes")
+		}
+		if dst.Len() > 0 {
+			dst.WriteByte(' ')
+		}
+		fmt.Fprintf(&dst, "%d", v)
+		b = b[n:]
+	}
+	return []byte(dst.String()), nil
+}
+
+func zigzagEnc(src []byte) ([]byte, error) {
+	words := strings.Fields(string(src))
+	buf := make([]byte, binary.MaxVarintLen64)
+	var dst bytes.Buffer
+	for _, word := range words {
+		v, err := strconv.ParseInt(word, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		n := binary.PutVarint(buf, v)
+		dst.WriteString(hex.EncodeToString(buf[:n]))
+	}
+	return dst.Bytes(), nil
+}
+
 func hexExtEnc(src []byte) (dst []byte, err error) {
 	return []byte(hex.Dump(src)), nil
 }
 
-func base64Enc(src []byte) (dst []byte, err error) {
-	dst = make([]byte, base64.StdEncoding.EncodedLen(len(src)))
-	base64.StdEncoding.Encode(dst, src)
-	return
+func base64Enc(w io.Writer, r io.Reader) error {
+	enc := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := io.Copy(enc, r); err != nil {
+		return err
+	}
+	return enc.Close()
 }
 
-func base64Dec(src []byte) ([]byte, error) {
-	dst := make([]byte, base64.StdEncoding.DecodedLen(len(src)))
-	n, err := base64.StdEncoding.Decode(dst, src)
-	return dst[:n], err
+func base64Dec(w io.Writer, r io.Reader) error {
+	_, err := io.Copy(w, base64.NewDecoder(base64.StdEncoding, r))
+	return err
 }
 
-func base64URLEnc(src []byte) (dst []byte, err error) {
-	dst = make([]byte, base64.URLEncoding.EncodedLen(len(src)))
-	base64.URLEncoding.Encode(dst, src)
-	return
+func base64URLEnc(w io.Writer, r io.Reader) error {
+	enc := base64.NewEncoder(base64.URLEncoding, w)
+	if _, err := io.Copy(enc, r); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func base64URLDec(w io.Writer, r io.Reader) error {
+	_, err := io.Copy(w, base64.NewDecoder(base64.URLEncoding, r))
+	return err
 }
 
-func base64URLDec(src []byte) ([]byte, error) {
-	dst := make([]byte, base64.URLEncoding.DecodedLen(len(src)))
-	n, err := base64.URLEncoding.Decode(dst, src)
+func ascii85Enc(src []byte) (dst []byte, err error) {
+	dst = make([]byte, ascii85.MaxEncodedLen(len(src)))
+	n := ascii85.Encode(dst, src)
+	return dst[:n], nil
+}
+
+func ascii85Dec(src []byte) ([]byte, error) {
+	dst := make([]byte, len(src))
+	n, _, err := ascii85.Decode(dst, src, true)
 	return dst[:n], err
 }
 
+const z85Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ.-:+=^!/*?&<>()[]{}@%$#"
+
+func z85Enc(src []byte) ([]byte, error) {
+	if len(src)%4 != 0 {
+		return nil, fmt.Errorf("z85: input length %d is not a multiple of 4", len(src))
+	}
+	var dst strings.Builder
+	for i := 0; i < len(src); i += 4 {
+		value := uint32(src[i])<<24 | uint32(src[i+1])<<16 | uint32(src[i+2])<<8 | uint32(src[i+3])
+		var chunk [5]byte
+		for j := 4; j >= 0; j-- {
+			chunk[j] = z85Alphabet[value%85]
+			value /= 85
+		}
+		dst.Write(chunk[:])
+	}
+	return []byte(dst.String()), nil
+}
+
+func z85Dec(src []byte) ([]byte, error) {
+	if len(src)%5 != 0 {
+		return nil, fmt.Errorf("z85: input length %d is not a multiple of 5", len(src))
+	}
+	dst := make([]byte, 0, len(src)/5*4)
+	for i := 0; i < len(src); i += 5 {
+		var value uint32
+		for j := 0; j < 5; j++ {
+			idx := strings.IndexByte(z85Alphabet, src[i+j])
+			if idx < 0 {
+				return nil, fmt.Errorf("z85: invalid character %q", src[i+j])
+			}
+			value = value*85 + uint32(idx)
+		}
+		dst = append(dst, byte(value>>24), byte(value>>16), byte(value>>8), byte(value))
+	}
+	return dst, nil
+}
+
 func rot13(src []byte) (dst []byte, err error) {
 	dst = src[:0]
 	for _, b := range src {
@@ -259,47 +624,80 @@ func rot13(src []byte) (dst []byte, err error) {
 	return
 }
 
-func base32Enc(src []byte) (dst []byte, err error) {
-	dst = make([]byte, base32.StdEncoding.EncodedLen(len(src)))
-	base32.StdEncoding.Encode(dst, src)
-	return
+func base32Enc(w io.Writer, r io.Reader) error {
+	enc := base32.NewEncoder(base32.StdEncoding, w)
+	if _, err := io.Copy(enc, r); err != nil {
+		return err
+	}
+	return enc.Close()
 }
 
-func base32Dec(src []byte) ([]byte, error) {
-	dst := make([]byte, base32.StdEncoding.DecodedLen(len(src)))
-	n, err := base32.StdEncoding.Decode(dst, src)
-	return dst[:n], err
+func base32Dec(w io.Writer, r io.Reader) error {
+	_, err := io.Copy(w, base32.NewDecoder(base32.StdEncoding, r))
+	return err
 }
 
-func base32HexEnc(src []byte) (dst []byte, err error) {
-	dst = make([]byte, base32.HexEncoding.EncodedLen(len(src)))
-	base32.HexEncoding.Encode(dst, src)
-	return
+func base32HexEnc(w io.Writer, r io.Reader) error {
+	enc := base32.NewEncoder(base32.HexEncoding, w)
+	if _, err := io.Copy(enc, r); err != nil {
+		return err
+	}
+	return enc.Close()
 }
 
-func base32HexDec(src []byte) ([]byte, error) {
-	dst := make([]byte, base32.HexEncoding.DecodedLen(len(src)))
-	n, err := base32.HexEncoding.Decode(dst, src)
-	return dst[:n], err
+func base32HexDec(w io.Writer, r io.Reader) error {
+	_, err := io.Copy(w, base32.NewDecoder(base32.HexEncoding, r))
+	return err
 }
 
 var crockfordEnc = base32.NewEncoding("0123456789ABCDEFGHJKMNPQRSTVWXYZ")
 
-func base32CrockfordEnc(src []byte) (dst []byte, err error) {
-	dst = make([]byte, crockfordEnc.EncodedLen(len(src)))
-	crockfordEnc.Encode(dst, src)
-	return
+func base32CrockfordEnc(w io.Writer, r io.Reader) error {
+	enc := base32.NewEncoder(crockfordEnc, w)
+	if _, err := io.Copy(enc, r); err != nil {
+		return err
+	}
+	return enc.Close()
 }
 
-func base32CrockfordDec(src []byte) ([]byte, error) {
-	src = bytes.ToUpper(src)
-	src = bytes.Replace(src, []byte("I"), []byte("1"), -1)
-	src = bytes.Replace(src, []byte("L"), []byte("1"), -1)
-	src = bytes.Replace(src, []byte("O"), []byte("0"), -1)
-	src = bytes.Replace(src, []byte("-"), nil, -1)
-	dst := make([]byte, crockfordEnc.DecodedLen(len(src)))
-	n, err := crockfordEnc.Decode(dst, src)
-	return dst[:n], err
+func base32CrockfordDec(w io.Writer, r io.Reader) error {
+	_, err := io.Copy(w, base32.NewDecoder(crockfordEnc, newCrockfordNormalizer(r)))
+	return err
+}
+
+// crockfordNormalizer upper-cases input and applies Crockford's
+// look-alike substitutions (I/L -> 1, O -> 0) and dash stripping a chunk
+// at a time, so decoding stays streaming instead of requiring the whole
+// input up front.
+type crockfordNormalizer struct {
+	r io.Reader
+}
+
+func newCrockfordNormalizer(r io.Reader) io.Reader {
+	return &crockfordNormalizer{r: r}
+}
+
+func (c *crockfordNormalizer) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	w := 0
+	for i := 0; i < n; i++ {
+		b := p[i]
+		if b == '-' {
+			continue
+		}
+		if b >= 'a' && b <= 'z' {
+			b -= 'a' - 'A'
+		}
+		switch b {
+		case 'I', 'L':
+			b = '1'
+		case 'O':
+			b = '0'
+		}
+		p[w] = b
+		w++
+	}
+	return w, err
 }
 
 func goEnc(src []byte) ([]byte, error) {
@@ -378,20 +776,602 @@ func codepointEnc(src []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-func quotedPrintableEnc(src []byte) ([]byte, error) {
+func quotedPrintableEnc(w io.Writer, r io.Reader) error {
+	qw := quotedprintable.NewWriter(w)
+	if _, err := io.Copy(qw, r); err != nil {
+		return err
+	}
+	return qw.Close()
+}
+
+func quotedPrintableDec(w io.Writer, r io.Reader) error {
+	_, err := io.Copy(w, quotedprintable.NewReader(r))
+	return err
+}
+
+var derUniversalTagNames = map[int]string{
+	1:  "BOOLEAN",
+	2:  "INTEGER",
+	3:  "BIT STRING",
+	4:  "OCTET STRING",
+	5:  "NULL",
+	6:  "OBJECT IDENTIFIER",
+	10: "ENUMERATED",
+	12: "UTF8String",
+	16: "SEQUENCE",
+	17: "SET",
+	19: "PrintableString",
+	22: "IA5String",
+	23: "UTCTime",
+	24: "GeneralizedTime",
+	30: "BMPString",
+}
+
+var derClassNames = [4]string{"universal", "application", "context", "private"}
+
+// derDec walks a DER/BER TLV stream and renders it as an indented tree,
+// similar in spirit to `openssl asn1parse`.
+func derDec(src []byte) ([]byte, error) {
 	var buf bytes.Buffer
-	w := quotedprintable.NewWriter(&buf)
-	if _, err := w.Write(src); err != nil {
+	if err := derWalk(src, 0, &buf); err != nil {
 		return nil, err
 	}
-	if err := w.Close(); err != nil {
+	return buf.Bytes(), nil
+}
+
+func derWalk(data []byte, depth int, buf *bytes.Buffer) error {
+	for len(data) > 0 {
+		var raw asn1.RawValue
+		rest, err := asn1.Unmarshal(data, &raw)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%s%s\n", strings.Repeat("  ", depth), derNodeLabel(raw))
+		if raw.IsCompound {
+			if err := derWalk(raw.Bytes, depth+1, buf); err != nil {
+				return err
+			}
+		}
+		data = rest
+	}
+	return nil
+}
+
+func derNodeLabel(raw asn1.RawValue) string {
+	tagName := fmt.Sprintf("tag %d", raw.Tag)
+	if raw.Class == asn1.ClassUniversal {
+		if name, ok := derUniversalTagNames[raw.Tag]; ok {
+			tagName = name
+		}
+	}
+	label := fmt.Sprintf("[%s %d] %s (len=%d)", derClassNames[raw.Class], raw.Tag, tagName, len(raw.Bytes))
+	if !raw.IsCompound {
+		label += fmt.Sprintf(" hex=%s", hex.EncodeToString(raw.Bytes))
+		if utf8.Valid(raw.Bytes) {
+			label += fmt.Sprintf(" utf8=%q", string(raw.Bytes))
+		}
+	}
+	return label
+}
+
+// derEnc accepts a small textual grammar, e.g.
+//
+//	SEQUENCE { INTEGER 42, UTF8String "hi", OID 1.2.840.113549 }
+//
+// and produces the corresponding DER-encoded bytes.
+func derEnc(src []byte) ([]byte, error) {
+	toks := derTokenize(string(src))
+	p := &derParser{toks: toks}
+	node, err := p.parseValue()
+	if err != nil {
 		return nil, err
 	}
+	if p.pos != len(p.toks) {
+		return nil, fmt.Errorf("der: unexpected trailing input %q", strings.Join(p.toks[p.pos:], " "))
+	}
+	return derMarshal(node)
+}
 
-	return buf.Bytes(), nil
+type derNode struct {
+	typ      string
+	value    string
+	children []derNode
+}
+
+type derParser struct {
+	toks []string
+	pos  int
 }
 
-func quotedPrintableDec(src []byte) ([]byte, error) {
-	r := quotedprintable.NewReader(bytes.NewReader(src))
-	return io.ReadAll(r)
+func (p *derParser) parseValue() (derNode, error) {
+	if p.pos >= len(p.toks) {
+		return derNode{}, fmt.Errorf("der: unexpected end of input")
+	}
+	typ := p.toks[p.pos]
+	p.pos++
+	switch typ {
+	case "SEQUENCE", "SET":
+		if p.pos >= len(p.toks) || p.toks[p.pos] != "{" {
+			return derNode{}, fmt.Errorf("der: expected '{' after %s", typ)
+		}
+		p.pos++
+		node := derNode{typ: typ}
+		for {
+			if p.pos >= len(p.toks) {
+				return derNode{}, fmt.Errorf("der: unterminated %s", typ)
+			}
+			if p.toks[p.pos] == "}" {
+				p.pos++
+				break
+			}
+			child, err := p.parseValue()
+			if err != nil {
+				return derNode{}, err
+			}
+			node.children = append(node.children, child)
+			if p.pos < len(p.toks) && p.toks[p.pos] == "," {
+				p.pos++
+			}
+		}
+		return node, nil
+	default:
+		if p.pos >= len(p.toks) {
+			return derNode{}, fmt.Errorf("der: missing value for %s", typ)
+		}
+		val := strings.Trim(p.toks[p.pos], `"`)
+		p.pos++
+		return derNode{typ: typ, value: val}, nil
+	}
+}
+
+func derTokenize(s string) []string {
+	var toks []string
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '{' || c == '}' || c == ',':
+			toks = append(toks, string(c))
+			i++
+		case c == '"':
+			j := i + 1
+			for j < len(s) && s[j] != '"' {
+				j++
+			}
+			if j < len(s) {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\n\r{},", rune(s[j])) {
+				j++
+			}
+			toks = append(toks, s[i:j])
+			i = j
+		}
+	}
+	return toks
+}
+
+func derMarshal(n derNode) ([]byte, error) {
+	switch n.typ {
+	case "SEQUENCE":
+		return derMarshalCompound(n, 16)
+	case "SET":
+		return derMarshalCompound(n, 17)
+	case "INTEGER":
+		v, err := strconv.ParseInt(n.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("der: invalid INTEGER %q: %w", n.value, err)
+		}
+		return derTLV(asn1.ClassUniversal, 2, false, derIntegerBytes(v)), nil
+	case "OID":
+		body, err := derOIDBytes(n.value)
+		if err != nil {
+			return nil, err
+		}
+		return derTLV(asn1.ClassUniversal, 6, false, body), nil
+	case "UTF8String":
+		return derTLV(asn1.ClassUniversal, 12, false, []byte(n.value)), nil
+	case "PrintableString":
+		return derTLV(asn1.ClassUniversal, 19, false, []byte(n.value)), nil
+	case "IA5String":
+		return derTLV(asn1.ClassUniversal, 22, false, []byte(n.value)), nil
+	case "OCTETSTRING":
+		body, err := hex.DecodeString(n.value)
+		if err != nil {
+			return nil, fmt.Errorf("der: invalid OCTETSTRING hex %q: %w", n.value, err)
+		}
+		return derTLV(asn1.ClassUniversal, 4, false, body), nil
+	case "NULL":
+		return derTLV(asn1.ClassUniversal, 5, false, nil), nil
+	case "BOOLEAN":
+		if n.value == "true" {
+			return derTLV(asn1.ClassUniversal, 1, false, []byte{0xff}), nil
+		}
+		return derTLV(asn1.ClassUniversal, 1, false, []byte{0x00}), nil
+	default:
+		return nil, fmt.Errorf("der: unsupported type %q", n.typ)
+	}
+}
+
+func derMarshalCompound(n derNode, tag int) ([]byte, error) {
+	var body []byte
+	for _, c := range n.children {
+		b, err := derMarshal(c)
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, b...)
+	}
+	return derTLV(asn1.ClassUniversal, tag, true, body), nil
+}
+
+func derTLV(class, tag int, compound bool, body []byte) []byte {
+	b0 := byte(class << 6)
+	if compound {
+		b0 |= 0x20
+	}
+	b0 |= byte(tag)
+	out := []byte{b0}
+	out = append(out, derLengthBytes(len(body))...)
+	return append(out, body...)
+}
+
+func derLengthBytes(n int) []byte {
+	if n < 128 {
+		return []byte{byte(n)}
+	}
+	var b []byte
+	for n > 0 {
+		b = append([]byte{byte(n & 0xff)}, b...)
+		n >>= 8
+	}
+	return append([]byte{byte(0x80 | len(b))}, b...)
+}
+
+func derIntegerBytes(v int64) []byte {
+	if v == 0 {
+		return []byte{0}
+	}
+	neg := v < 0
+	uv := uint64(v)
+	if neg {
+		uv = uint64(-v)
+	}
+	var b []byte
+	for uv > 0 {
+		b = append([]byte{byte(uv)}, b...)
+		uv >>= 8
+	}
+	if neg {
+		for i := range b {
+			b[i] = ^b[i]
+		}
+		carry := 1
+		for i := len(b) - 1; i >= 0 && carry > 0; i-- {
+			sum := int(b[i]) + carry
+			b[i] = byte(sum)
+			carry = sum >> 8
+		}
+		if b[0]&0x80 == 0 {
+			b = append([]byte{0xff}, b...)
+		}
+	} else if b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return b
+}
+
+func derOIDBytes(s string) ([]byte, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("der: OID %q needs at least two components", s)
+	}
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("der: invalid OID component %q", p)
+		}
+		nums[i] = n
+	}
+	var body []byte
+	body = append(body, byte(nums[0]*40+nums[1]))
+	for _, n := range nums[2:] {
+		body = append(body, derBase128(n)...)
+	}
+	return body, nil
+}
+
+func csvDelimFor(def rune) rune {
+	if *csvDelim != "" {
+		return rune((*csvDelim)[0])
+	}
+	return def
+}
+
+func checkCSVQuote() error {
+	if *csvQuote != `"` {
+		return fmt.Errorf(`csv/tsv: -quote %q is not supported, encoding/csv always quotes with '"'`, *csvQuote)
+	}
+	return nil
+}
+
+func csvDec(w io.Writer, r io.Reader) error { return decodeCSV(w, r, csvDelimFor(',')) }
+func csvEnc(w io.Writer, r io.Reader) error { return encodeCSV(w, r, csvDelimFor(',')) }
+func tsvDec(w io.Writer, r io.Reader) error { return decodeCSV(w, r, csvDelimFor('\t')) }
+func tsvEnc(w io.Writer, r io.Reader) error { return encodeCSV(w, r, csvDelimFor('\t')) }
+
+// decodeCSV reads CSV/TSV records and emits NDJSON: one JSON array per
+// row, or (with -header) one JSON object per row keyed by the first
+// row's fields. encoding/csv fixes the quote character at '"'; -quote
+// exists to surface that limitation rather than silently ignoring it.
+func decodeCSV(w io.Writer, r io.Reader, delim rune) error {
+	if err := checkCSVQuote(); err != nil {
+		return err
+	}
+	cr := csv.NewReader(r)
+	cr.Comma = delim
+	cr.FieldsPerRecord = -1
+
+	enc := json.NewEncoder(w)
+	var header []string
+	first := true
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if *csvHeader && first {
+			header = row
+			first = false
+			continue
+		}
+		first = false
+		if *csvHeader {
+			obj := make(map[string]string, len(header))
+			for i, h := range header {
+				if i < len(row) {
+					obj[h] = row[i]
+				}
+			}
+			if err := enc.Encode(obj); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+}
+
+// encodeCSV reads NDJSON rows (arrays, or objects with -header) and
+// writes RFC 4180 CSV/TSV. With -header, the column order is taken from
+// the sorted keys of the first object.
+func encodeCSV(w io.Writer, r io.Reader, delim rune) error {
+	if err := checkCSVQuote(); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = delim
+	dec := json.NewDecoder(r)
+
+	var header []string
+	for {
+		if *csvHeader {
+			var obj map[string]string
+			if err := dec.Decode(&obj); err == io.EOF {
+				break
+			} else if err != nil {
+				return err
+			}
+			if header == nil {
+				header = make([]string, 0, len(obj))
+				for k := range obj {
+					header = append(header, k)
+				}
+				sort.Strings(header)
+				if err := cw.Write(header); err != nil {
+					return err
+				}
+			}
+			row := make([]string, len(header))
+			for i, k := range header {
+				row[i] = obj[k]
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+			continue
+		}
+		var row []string
+		if err := dec.Decode(&row); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func sha256Enc(w io.Writer, r io.Reader) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, hex.EncodeToString(h.Sum(nil)))
+	return err
+}
+
+func sha512Enc(w io.Writer, r io.Reader) error {
+	h := sha512.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, hex.EncodeToString(h.Sum(nil)))
+	return err
+}
+
+func md5Enc(w io.Writer, r io.Reader) error {
+	h := md5.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, hex.EncodeToString(h.Sum(nil)))
+	return err
+}
+
+func blake2bEnc(w io.Writer, r io.Reader) error {
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, hex.EncodeToString(h.Sum(nil)))
+	return err
+}
+
+// bcryptEnc hashes stdin (the password) with -cost and emits the
+// standard $2a$... encoded form.
+func bcryptEnc(w io.Writer, r io.Reader) error {
+	password, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword(bytes.TrimRight(password, "\n"), *hashCost)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(hash)
+	return err
+}
+
+// bcryptVerify reads "hash\npassword" from stdin and reports a match via
+// exit status: nil (exit 0) if they match, errHashMismatch (exit 1) if not.
+func bcryptVerify(w io.Writer, r io.Reader) error {
+	hash, password, err := readHashAndPassword(r)
+	if err != nil {
+		return err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return errHashMismatch
+	}
+	_, err = io.WriteString(w, "ok")
+	return err
+}
+
+const (
+	argon2Memory  = 64 * 1024 // KiB
+	argon2Threads = 4
+	argon2KeyLen  = 32
+)
+
+// argon2idEnc hashes stdin (the password) with a random salt and -cost
+// iterations, and emits the standard PHC $argon2id$v=...$m=...,t=...,p=...$salt$hash form.
+func argon2idEnc(w io.Writer, r io.Reader) error {
+	password, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	password = bytes.TrimRight(password, "\n")
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	time := uint32(*hashCost)
+	hash := argon2.IDKey(password, salt, time, argon2Memory, argon2Threads, argon2KeyLen)
+
+	encoded := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argon2Memory, time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash))
+	_, err = io.WriteString(w, encoded)
+	return err
+}
+
+// argon2idVerify reads "hash\npassword" from stdin and reports a match
+// via exit status, same convention as bcryptVerify.
+func argon2idVerify(w io.Writer, r io.Reader) error {
+	encoded, password, err := readHashAndPassword(r)
+	if err != nil {
+		return err
+	}
+	ok, err := argon2idMatches(encoded, []byte(password))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errHashMismatch
+	}
+	_, err = io.WriteString(w, "ok")
+	return err
+}
+
+func argon2idMatches(encoded string, password []byte) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false, fmt.Errorf("argon2id: malformed hash string %q", encoded)
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, fmt.Errorf("argon2id: malformed version field: %w", err)
+	}
+	var mem, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &mem, &time, &threads); err != nil {
+		return false, fmt.Errorf("argon2id: malformed parameter field: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, fmt.Errorf("argon2id: malformed salt: %w", err)
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, fmt.Errorf("argon2id: malformed hash: %w", err)
+	}
+	got := argon2.IDKey(password, salt, time, mem, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+func readHashAndPassword(r io.Reader) (hash, password string, err error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", "", err
+	}
+	lines := strings.SplitN(strings.TrimRight(string(b), "\n"), "\n", 2)
+	if len(lines) != 2 {
+		return "", "", fmt.Errorf("expected \"hash\\npassword\" on stdin")
+	}
+	return lines[0], lines[1], nil
+}
+
+func derBase128(n int) []byte {
+	if n == 0 {
+		return []byte{0}
+	}
+	var b []byte
+	for n > 0 {
+		b = append([]byte{byte(n & 0x7f)}, b...)
+		n >>= 7
+	}
+	for i := 0; i < len(b)-1; i++ {
+		b[i] |= 0x80
+	}
+	return b
 }